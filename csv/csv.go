@@ -0,0 +1,254 @@
+// Package csv converts JSON-shaped data into CSV by flattening each record with flatten and
+// computing the union of the resulting keys as the header row.
+package csv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/singular-labs/flatten"
+)
+
+// HeaderSortMode controls the order of the non-pinned headers ToCSV/CSVWriter discover across
+// records. PinnedHeaders, if set, always come first regardless of HeaderSortMode.
+type HeaderSortMode int
+
+const (
+	// InsertionOrder (the default) keeps headers in the order they were first seen across records.
+	InsertionOrder HeaderSortMode = iota
+
+	// Alphabetical sorts the non-pinned headers lexicographically.
+	Alphabetical
+
+	// PinnedThenAlphabetical is Alphabetical spelled out for callers who also set PinnedHeaders
+	// and want that combination to be explicit at the call site.
+	PinnedThenAlphabetical
+)
+
+// ArrayPolicy controls how a nested array surfaces as a CSV cell.
+type ArrayPolicy int
+
+const (
+	// ExpandArrayIndices (the default) flattens arrays element by element, e.g. "tags.0", "tags.1",
+	// including arrays of primitives, which flatten.Flatten itself keeps as a single value.
+	ExpandArrayIndices ArrayPolicy = iota
+
+	// EncodeArraysAsJSON keeps each array as a single cell, JSON-encoded.
+	EncodeArraysAsJSON
+)
+
+// CSVOptions configures ToCSV and NewCSVWriter.
+type CSVOptions struct {
+	// Style is the flatten.SeparatorStyle used to build flat keys from each nested record.
+	// Defaults to flatten.DotStyle.
+	Style flatten.SeparatorStyle
+
+	// PinnedHeaders appear first, in this order, ahead of any other discovered header.
+	PinnedHeaders []string
+
+	// HeaderSort orders the remaining, non-pinned headers; see HeaderSortMode.
+	HeaderSort HeaderSortMode
+
+	// Delimiter is the field delimiter written between cells. Defaults to ','.
+	Delimiter rune
+
+	// CRLF selects \r\n line endings instead of \n.
+	CRLF bool
+
+	// Arrays controls how nested arrays are represented; see ArrayPolicy.
+	Arrays ArrayPolicy
+}
+
+// ToCSV flattens a JSON array of objects into a CSV table: a header row followed by one row per
+// record, with missing cells left blank.
+func ToCSV(records []interface{}, opts CSVOptions) ([][]string, error) {
+	flatRecords := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		flat, err := flattenRecord(r, opts)
+		if err != nil {
+			return nil, err
+		}
+		flatRecords[i] = flat
+	}
+
+	headers := buildHeaders(flatRecords, opts)
+
+	rows := make([][]string, 0, len(flatRecords)+1)
+	rows = append(rows, headers)
+	for _, flat := range flatRecords {
+		rows = append(rows, rowFor(headers, flat))
+	}
+
+	return rows, nil
+}
+
+// CSVWriter streams records out as CSV. Because the full header set isn't known until every
+// record has been seen, it buffers records written before the first Flush; Flush establishes the
+// header row from everything buffered so far (or from PinnedHeaders alone, if that's all that was
+// given) and writes it, along with the buffered rows. Calls to WriteRecord after that are written
+// straight through against the now-fixed header set.
+type CSVWriter struct {
+	w        *csv.Writer
+	opts     CSVOptions
+	buffered []map[string]interface{}
+	headers  []string
+	started  bool
+}
+
+// NewCSVWriter returns a CSVWriter that writes to w.
+func NewCSVWriter(w io.Writer, opts CSVOptions) *CSVWriter {
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Delimiter
+	cw.UseCRLF = opts.CRLF
+
+	return &CSVWriter{w: cw, opts: opts}
+}
+
+// WriteRecord flattens v and queues it for output. Before the first Flush, records are only
+// buffered, since the header row can't be written until the full key set is known.
+func (cw *CSVWriter) WriteRecord(v interface{}) error {
+	flat, err := flattenRecord(v, cw.opts)
+	if err != nil {
+		return err
+	}
+
+	if cw.started {
+		return cw.w.Write(rowFor(cw.headers, flat))
+	}
+
+	cw.buffered = append(cw.buffered, flat)
+	return nil
+}
+
+// Flush establishes the header row (on the first call) and writes out any buffered records, then
+// flushes the underlying encoding/csv.Writer. It must be called at least once for any output to
+// reach w, and again after the stream's last WriteRecord to ensure everything is written.
+func (cw *CSVWriter) Flush() error {
+	if !cw.started {
+		cw.headers = buildHeaders(cw.buffered, cw.opts)
+		if err := cw.w.Write(cw.headers); err != nil {
+			return err
+		}
+
+		for _, flat := range cw.buffered {
+			if err := cw.w.Write(rowFor(cw.headers, flat)); err != nil {
+				return err
+			}
+		}
+
+		cw.buffered = nil
+		cw.started = true
+	}
+
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func flattenRecord(v interface{}, opts CSVOptions) (map[string]interface{}, error) {
+	if opts.Style == nil {
+		opts.Style = flatten.DotStyle
+	}
+
+	fo := flatten.FlattenOptions{Style: opts.Style}
+	if opts.Arrays == EncodeArraysAsJSON {
+		fo.DontFlattenArrays = true
+	}
+
+	flat, err := flatten.FlattenWithOptions(v, fo)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Arrays {
+	case EncodeArraysAsJSON:
+		for k, v := range flat {
+			if _, ok := v.([]interface{}); !ok {
+				continue
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			flat[k] = string(b)
+		}
+	default:
+		expandArrays(flat, opts.Style)
+	}
+
+	return flat, nil
+}
+
+// expandArrays rewrites any []interface{} value left whole by FlattenWithOptions (it keeps a
+// primitive-only array as a single leaf) into one "key.0", "key.1", ... entry per element, so
+// ExpandArrayIndices' documented element-by-element behavior holds even for those arrays.
+func expandArrays(flat map[string]interface{}, style flatten.SeparatorStyle) {
+	for k, v := range flat {
+		arr, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		delete(flat, k)
+		for i, item := range arr {
+			flat[style.Encode(k, strconv.Itoa(i), false)] = item
+		}
+	}
+}
+
+func buildHeaders(records []map[string]interface{}, opts CSVOptions) []string {
+	pinned := make(map[string]bool, len(opts.PinnedHeaders))
+	for _, h := range opts.PinnedHeaders {
+		pinned[h] = true
+	}
+
+	var discovered []string
+	seen := make(map[string]bool)
+	for _, flat := range records {
+		keys := make([]string, 0, len(flat))
+		for k := range flat {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if pinned[k] || seen[k] {
+				continue
+			}
+			seen[k] = true
+			discovered = append(discovered, k)
+		}
+	}
+
+	if opts.HeaderSort == Alphabetical || opts.HeaderSort == PinnedThenAlphabetical {
+		sort.Strings(discovered)
+	}
+
+	headers := make([]string, 0, len(opts.PinnedHeaders)+len(discovered))
+	headers = append(headers, opts.PinnedHeaders...)
+	headers = append(headers, discovered...)
+	return headers
+}
+
+func rowFor(headers []string, flat map[string]interface{}) []string {
+	row := make([]string, len(headers))
+	for i, h := range headers {
+		if v, ok := flat[h]; ok {
+			row[i] = cellString(v)
+		}
+	}
+	return row
+}
+
+func cellString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", v)
+}
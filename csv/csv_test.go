@@ -0,0 +1,120 @@
+package csv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/singular-labs/flatten"
+)
+
+func TestToCSV(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"id": "1", "name": "Homer", "address": map[string]interface{}{"city": "Springfield"}},
+		map[string]interface{}{"id": "2", "name": "Marge", "nickname": "Marge"},
+	}
+
+	got, err := ToCSV(records, CSVOptions{Style: flatten.DotStyle, PinnedHeaders: []string{"id", "name"}})
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+
+	want := [][]string{
+		{"id", "name", "address.city", "nickname"},
+		{"1", "Homer", "Springfield", ""},
+		{"2", "Marge", "", "Marge"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestToCSVAlphabeticalHeaders(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"z": "1", "a": "2"},
+	}
+
+	got, err := ToCSV(records, CSVOptions{Style: flatten.DotStyle, HeaderSort: Alphabetical})
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+
+	want := [][]string{{"a", "z"}, {"2", "1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestToCSVZeroValueOptionsDefaultsToDotStyle(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"a": map[string]interface{}{"b": "c"}, "tags": []interface{}{"x", "y"}},
+	}
+
+	got, err := ToCSV(records, CSVOptions{})
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+
+	want := [][]string{{"a.b", "tags.0", "tags.1"}, {"c", "x", "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestToCSVExpandsPrimitiveArrays(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"tags": []interface{}{"a", "b"}},
+	}
+
+	got, err := ToCSV(records, CSVOptions{Style: flatten.DotStyle})
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+
+	want := [][]string{{"tags.0", "tags.1"}, {"a", "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestToCSVEncodeArraysAsJSON(t *testing.T) {
+	records := []interface{}{
+		map[string]interface{}{"tags": []interface{}{"a", "b"}},
+	}
+
+	got, err := ToCSV(records, CSVOptions{Style: flatten.DotStyle, Arrays: EncodeArraysAsJSON})
+	if err != nil {
+		t.Fatalf("failed to convert: %v", err)
+	}
+
+	want := [][]string{{"tags"}, {`["a","b"]`}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestCSVWriterBuffersUntilFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, CSVOptions{Style: flatten.DotStyle})
+
+	if err := w.WriteRecord(map[string]interface{}{"a": "1", "b": "2"}); err != nil {
+		t.Fatalf("failed to write record: %v", err)
+	}
+	if err := w.WriteRecord(map[string]interface{}{"a": "3"}); err != nil {
+		t.Fatalf("failed to write record: %v", err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output before Flush, got: %q", buf.String())
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	want := "a,b\n1,2\n3,\n"
+	if buf.String() != want {
+		t.Errorf("mismatch, got: %q want: %q", buf.String(), want)
+	}
+}
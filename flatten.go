@@ -51,46 +51,43 @@ import (
 	"strconv"
 )
 
-// The presentation style of keys.
-type SeparatorStyle int
-
-const (
-	_ SeparatorStyle = iota
-
-	// Separate nested key components with dots, e.g. "a.b.1.c.d"
-	DotStyle
-
-	// Separate nested key components with slashs, e.g. "a/b/1/c/d"
-	SlashStyle
-
-	// Separate ala Rails, e.g. "a[b][c][1][d]"
-	RailsStyle
-)
-
 // Nested input must be a map or slice
 var NotValidInputError = errors.New("Not a valid input: map or slice")
 
 // Flatten generates a flat map from a nested one.  The original may include values of type map, slice and scalar,
 // but not struct.  Keys in the flat map will be a compound of descending map keys and slice iterations.
 // The presentation of keys is set by style.  A prefix is joined to each key.
+//
+// Flatten is a thin wrapper around FlattenWithOptions for the common case; use FlattenWithOptions
+// directly for control over traversal depth, array handling, and key transforms.
 func Flatten(nested map[string]interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
-	flatmap := make(map[string]interface{})
-
-	err := flatten(true, flatmap, nested, prefix, style)
-	if err != nil {
-		return nil, err
-	}
+	return FlattenWithOptions(nested, FlattenOptions{Prefix: prefix, Style: style})
+}
 
-	return flatmap, nil
+// FlattenValue generates a flat map from a nested value of any kind Flatten accepts, plus Go
+// structs, pointers to them, and map[interface{}]interface{}.  Structs are walked with reflect,
+// honoring `json` tags (including "-" and "omitempty") the same way encoding/json would, but
+// without paying for a marshal/unmarshal round-trip.  Anonymous embedded structs are promoted,
+// time.Time and anything implementing json.Marshaler are treated as leaves, and channels or funcs
+// return an *ErrUnsupportedType instead of panicking.
+func FlattenValue(v interface{}, prefix string, style SeparatorStyle) (map[string]interface{}, error) {
+	return FlattenWithOptions(v, FlattenOptions{Prefix: prefix, Style: style})
 }
 
 // FlattenAll generates a flat array from a nested map.  The original may include values of type map, slice
 // and scalar, but not struct.  Items in the flat array will be a compound of descending map keys and slice
 // iterations.  The presentation of keys is set by style.  A prefix is joined to each key.
 func FlattenAll(nested interface{}, prefix string, style SeparatorStyle, sorted bool) ([]string, error) {
+	if style == nil {
+		style = DotStyle
+	}
+
 	result := []string{}
 
-	err := flattenAll(true, &result, nested, prefix, style)
+	err := FlattenIter(nested, prefix, style, func(key string, value interface{}) error {
+		result = appendLeaf(result, key, value, style)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +98,19 @@ func FlattenAll(nested interface{}, prefix string, style SeparatorStyle, sorted
 	return result, nil
 }
 
+// appendLeaf appends one "key.value" entry per scalar reachable from value, further expanding a
+// primitive array (which FlattenIter keeps whole, as a single leaf) index by index to match
+// FlattenAll's historical, fully-expanded output.
+func appendLeaf(result []string, key string, value interface{}, style SeparatorStyle) []string {
+	if arr, ok := value.([]interface{}); ok {
+		for i, v := range arr {
+			result = appendLeaf(result, enkey(false, key, strconv.Itoa(i), style), v, style)
+		}
+		return result
+	}
+	return append(result, fmt.Sprintf("%s.%v", key, value))
+}
+
 // FlattenString generates a flat JSON map from a nested one.  Keys in the flat map will be a compound of
 // descending map keys and slice iterations.  The presentation of keys is set by style.  A prefix is joined
 // to each key.
@@ -136,94 +146,6 @@ func allPrimitives(arr []interface{}) bool {
 	return true
 }
 
-func flatten(top bool, flatMap map[string]interface{}, nested interface{}, prefix string, style SeparatorStyle) error {
-	assign := func(newKey string, v interface{}) error {
-		shouldFlatten := false
-
-		switch v.(type) {
-		case []interface{}:
-			shouldFlatten = !allPrimitives(v.([]interface{}))
-		case map[string]interface{}:
-			shouldFlatten = true
-		}
-
-		if !shouldFlatten {
-			flatMap[newKey] = v
-			return nil
-		}
-
-		err := flatten(false, flatMap, v, newKey, style)
-		return err
-	}
-
-	switch nested.(type) {
-	case map[string]interface{}:
-		for k, v := range nested.(map[string]interface{}) {
-			newKey := enkey(top, prefix, k, style)
-			assign(newKey, v)
-		}
-	case []interface{}:
-		for i, v := range nested.([]interface{}) {
-			newKey := enkey(top, prefix, strconv.Itoa(i), style)
-			assign(newKey, v)
-		}
-	default:
-		return NotValidInputError
-	}
-
-	return nil
-}
-
-func flattenAll(top bool, result *[]string, nested interface{}, prefix string, style SeparatorStyle) error {
-	assign := func(newKey string, v interface{}) error {
-		switch v.(type) {
-
-		case map[string]interface{}, []interface{}:
-			if err := flattenAll(false, result, v, newKey, style); err != nil {
-				return err
-			}
-
-		default:
-			newKey := fmt.Sprintf("%s.%v", newKey, v)
-			*result = append(*result, newKey)
-		}
-
-		return nil
-	}
-
-	switch nested.(type) {
-	case map[string]interface{}:
-		for k, v := range nested.(map[string]interface{}) {
-			newKey := enkey(top, prefix, k, style)
-			assign(newKey, v)
-		}
-	case []interface{}:
-		for i, v := range nested.([]interface{}) {
-			newKey := enkey(top, prefix, strconv.Itoa(i), style)
-			assign(newKey, v)
-		}
-	default:
-		return NotValidInputError
-	}
-
-	return nil
-}
-
 func enkey(top bool, prefix, subkey string, style SeparatorStyle) string {
-	key := prefix
-
-	if top {
-		key += subkey
-	} else {
-		switch style {
-		case DotStyle:
-			key += "." + subkey
-		case SlashStyle:
-			key += "/" + subkey
-		case RailsStyle:
-			key += "[" + subkey + "]"
-		}
-	}
-
-	return key
+	return style.Encode(prefix, subkey, top)
 }
@@ -229,3 +229,19 @@ func TestFlattenString(t *testing.T) {
 		}
 	}
 }
+
+func TestFlattenAllNilStyleDefaultsToDotStyle(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": "c"},
+	}
+
+	got, err := FlattenAll(nested, "", nil, true)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := []string{"a.b.c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
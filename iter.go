@@ -0,0 +1,106 @@
+package flatten
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// FlattenIter walks nested the same way Flatten does, but invokes yield once per leaf key/value
+// pair instead of building a map[string]interface{} - useful when the flattened data is only
+// going to be consumed once (written to a file, summed, etc.) and materializing it all would
+// waste memory. A non-nil error returned from yield stops the walk immediately and is returned
+// from FlattenIter.
+func FlattenIter(nested interface{}, prefix string, style SeparatorStyle, yield func(key string, value interface{}) error) error {
+	if style == nil {
+		style = DotStyle
+	}
+	opts := FlattenOptions{Prefix: prefix, Style: style, ArrayIndexFormat: NumericIndexFormat}
+	return walk(0, nested, prefix, opts, yield)
+}
+
+// FlattenStream reads a sequence of top-level JSON values from r - typically one object per line,
+// as in newline-delimited JSON logs - decoding and flattening them one at a time via a
+// json.Decoder, so the whole input never has to fit in memory at once. yield is called once per
+// leaf key/value pair across every record, with prefix and style applied exactly as in
+// FlattenIter. A non-nil error from yield stops the walk and is returned immediately.
+func FlattenStream(r io.Reader, prefix string, style SeparatorStyle, yield func(key string, value interface{}) error) error {
+	dec := json.NewDecoder(r)
+
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := FlattenIter(v, prefix, style, yield); err != nil {
+			return err
+		}
+	}
+}
+
+// walk is the traversal shared by FlattenWithOptions, FlattenIter and FlattenStream: it
+// normalizes nested, descends according to opts, and invokes yield once per leaf.
+func walk(depth int, nested interface{}, prefix string, opts FlattenOptions, yield func(key string, value interface{}) error) error {
+	norm, isLeaf, err := prepareForFlatten(nested)
+	if err != nil {
+		return err
+	}
+	if isLeaf {
+		return NotValidInputError
+	}
+
+	canDescend := opts.MaxDepth == UnlimitedDepth || (opts.MaxDepth != NonNestedOnly && depth+1 <= opts.MaxDepth)
+
+	assign := func(newKey string, v interface{}) error {
+		norm, isLeaf, err := prepareForFlatten(v)
+		if err != nil {
+			return err
+		}
+
+		if !isLeaf {
+			_, isArray := norm.([]interface{})
+			skipArray := isArray && opts.DontFlattenArrays
+			skipPrimitiveArray := isArray && allPrimitives(norm.([]interface{}))
+
+			if !canDescend || skipArray || skipPrimitiveArray {
+				return yield(newKey, norm)
+			}
+
+			return walk(depth+1, norm, newKey, opts, yield)
+		}
+
+		return yield(newKey, norm)
+	}
+
+	transform := func(seg string) string {
+		if opts.KeyTransform != nil {
+			return opts.KeyTransform(seg)
+		}
+		return seg
+	}
+
+	switch n := norm.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			newKey := enkey(depth == 0, prefix, transform(k), opts.Style)
+			if err := assign(newKey, v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, v := range n {
+			newKey := enkey(depth == 0, prefix, transform(opts.ArrayIndexFormat(i)), opts.Style)
+			if err := assign(newKey, v); err != nil {
+				return err
+			}
+		}
+	default:
+		return NotValidInputError
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package flatten
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFlattenIter(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": "c"},
+		"d": []interface{}{"e", "f"},
+	}
+
+	got := map[string]interface{}{}
+	err := FlattenIter(nested, "", DotStyle, func(key string, value interface{}) error {
+		got[key] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"a.b": "c",
+		"d":   []interface{}{"e", "f"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestFlattenIterNilStyleDefaultsToDotStyle(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": "c"},
+	}
+
+	got := map[string]interface{}{}
+	err := FlattenIter(nested, "", nil, func(key string, value interface{}) error {
+		got[key] = value
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate: %v", err)
+	}
+
+	want := map[string]interface{}{"a.b": "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestFlattenIterYieldErrorStopsWalk(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": "1",
+		"b": "2",
+	}
+
+	seen := 0
+	boom := errors.New("boom")
+	err := FlattenIter(nested, "", DotStyle, func(key string, value interface{}) error {
+		seen++
+		return boom
+	})
+	if err != boom {
+		t.Fatalf("expected boom, got: %v", err)
+	}
+	if seen != 1 {
+		t.Errorf("expected walk to stop after the first yield, saw %d calls", seen)
+	}
+}
+
+func TestFlattenStream(t *testing.T) {
+	r := strings.NewReader(`{"a":1,"b":{"c":2}}
+{"a":3}
+`)
+
+	var keys []string
+	err := FlattenStream(r, "", DotStyle, func(key string, value interface{}) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to stream: %v", err)
+	}
+
+	sort.Strings(keys)
+	want := []string{"a", "a", "b.c"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("mismatch, got: %v want: %v", keys, want)
+	}
+}
+
+func TestFlattenStreamManyRecords(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.WriteString(`{"n":1}` + "\n")
+	}
+
+	count := 0
+	err := FlattenStream(&buf, "", DotStyle, func(key string, value interface{}) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to stream: %v", err)
+	}
+	if count != 1000 {
+		t.Errorf("expected 1000 leaves, got %d", count)
+	}
+}
@@ -0,0 +1,78 @@
+package flatten
+
+import "strconv"
+
+// Sentinel values for FlattenOptions.MaxDepth.
+const (
+	// UnlimitedDepth is the zero value of MaxDepth: nested structures are flattened to arbitrary
+	// depth, the same as Flatten.
+	UnlimitedDepth = 0
+
+	// NonNestedOnly flattens nothing below the top level: every top-level key is still emitted,
+	// but any map or slice value is kept as-is rather than expanded.
+	NonNestedOnly = -1
+)
+
+// ArrayIndexFormat renders a slice index into the key segment used for that element.
+// NumericIndexFormat (the default) and RailsIndexFormat cover the common cases; supply your own
+// func(int) string for anything else, e.g. zero-padded indices.
+type ArrayIndexFormat func(i int) string
+
+// NumericIndexFormat renders indices as plain decimal numbers: "0", "1", "2". This is the default.
+func NumericIndexFormat(i int) string { return strconv.Itoa(i) }
+
+// RailsIndexFormat renders every index as the literal "[]", Rails form-param style, so every
+// element of a "tags" array gets the same key segment instead of a numbered one: "tags.[]" under
+// DotStyle. The segment is escaped like any other, so RailsStyle renders it as `tags[\[\]]`.
+// Where elements produce the same compound key, FlattenWithOptions keeps the last element's value.
+func RailsIndexFormat(i int) string { return "[]" }
+
+// FlattenOptions configures FlattenWithOptions.  The zero value flattens exactly like Flatten
+// with DotStyle and no prefix.
+type FlattenOptions struct {
+	// Prefix is joined to every top-level key, same as Flatten's prefix argument.
+	Prefix string
+
+	// Style controls how compound keys are rendered: DotStyle, SlashStyle or RailsStyle. Defaults
+	// to DotStyle.
+	Style SeparatorStyle
+
+	// MaxDepth bounds how many levels of nesting are expanded before a subtree is kept as-is.
+	// See UnlimitedDepth (default) and NonNestedOnly.
+	MaxDepth int
+
+	// DontFlattenArrays keeps slices as single leaf values instead of expanding them index by
+	// index. Named so the zero value (false) preserves Flatten's existing behavior.
+	DontFlattenArrays bool
+
+	// ArrayIndexFormat renders the key segment for each slice element. Defaults to
+	// NumericIndexFormat.
+	ArrayIndexFormat ArrayIndexFormat
+
+	// KeyTransform, if set, is applied to every raw key segment (a map key, or a rendered array
+	// index) before it is joined into the compound key, e.g. for case-folding or sanitizing.
+	KeyTransform func(string) string
+}
+
+// FlattenWithOptions generates a flat map from a nested one, configured by opts instead of the
+// fixed (prefix, style) pair Flatten takes.  Accepts everything FlattenValue does:
+// maps, slices, structs, pointers and map[interface{}]interface{}.
+func FlattenWithOptions(nested interface{}, opts FlattenOptions) (map[string]interface{}, error) {
+	if opts.ArrayIndexFormat == nil {
+		opts.ArrayIndexFormat = NumericIndexFormat
+	}
+	if opts.Style == nil {
+		opts.Style = DotStyle
+	}
+
+	flatmap := make(map[string]interface{})
+	err := walk(0, nested, opts.Prefix, opts, func(key string, value interface{}) error {
+		flatmap[key] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return flatmap, nil
+}
@@ -0,0 +1,97 @@
+package flatten
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlattenWithOptionsMaxDepth(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{
+				"c": "d",
+			},
+		},
+	}
+
+	cases := []struct {
+		maxDepth int
+		want     map[string]interface{}
+	}{
+		{
+			UnlimitedDepth,
+			map[string]interface{}{"a.b.c": "d"},
+		},
+		{
+			NonNestedOnly,
+			map[string]interface{}{"a": nested["a"]},
+		},
+		{
+			1,
+			map[string]interface{}{"a.b": map[string]interface{}{"c": "d"}},
+		},
+	}
+
+	for i, c := range cases {
+		got, err := FlattenWithOptions(nested, FlattenOptions{Style: DotStyle, MaxDepth: c.maxDepth})
+		if err != nil {
+			t.Fatalf("%d: failed to flatten: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%d: mismatch, got: %v want: %v", i+1, got, c.want)
+		}
+	}
+}
+
+func TestFlattenWithOptionsZeroValueDefaultsToDotStyle(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": "c"},
+	}
+
+	got, err := FlattenWithOptions(nested, FlattenOptions{})
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"a.b": "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestFlattenWithOptionsDontFlattenArrays(t *testing.T) {
+	nested := map[string]interface{}{
+		"tags": []interface{}{"a", map[string]interface{}{"x": "y"}},
+	}
+
+	got, err := FlattenWithOptions(nested, FlattenOptions{Style: DotStyle, DontFlattenArrays: true})
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"tags": nested["tags"]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestFlattenWithOptionsArrayIndexFormatAndKeyTransform(t *testing.T) {
+	nested := map[string]interface{}{
+		"Tags": []interface{}{map[string]interface{}{"V": "a"}},
+	}
+
+	got, err := FlattenWithOptions(nested, FlattenOptions{
+		Style:            DotStyle,
+		ArrayIndexFormat: RailsIndexFormat,
+		KeyTransform:     strings.ToLower,
+	})
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"tags.[].v": "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
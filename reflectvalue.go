@@ -0,0 +1,194 @@
+package flatten
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedType is returned when a value cannot be represented as JSON-like data, e.g. a
+// channel or a function.
+type ErrUnsupportedType struct {
+	Type reflect.Type
+}
+
+func (e *ErrUnsupportedType) Error() string {
+	return fmt.Sprintf("flatten: unsupported type: %s", e.Type)
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// prepareForFlatten normalizes v into either a leaf value (isLeaf == true, returned as-is) or a
+// map[string]interface{} / []interface{} ready for the flatten walk.  Pointers are dereferenced,
+// structs and map[interface{}]interface{} are converted via reflection, and time.Time /
+// json.Marshaler values are kept as leaves rather than expanded.
+func prepareForFlatten(v interface{}) (out interface{}, isLeaf bool, err error) {
+	if v == nil {
+		return nil, true, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, true, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if isLeafType(rv) {
+		return rv.Interface(), true, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if m, ok := rv.Interface().(map[string]interface{}); ok {
+			return m, false, nil
+		}
+
+		m := make(map[string]interface{}, rv.Len())
+		for _, key := range rv.MapKeys() {
+			m[fmt.Sprintf("%v", key.Interface())] = rv.MapIndex(key).Interface()
+		}
+		return m, false, nil
+
+	case reflect.Slice, reflect.Array:
+		if arr, ok := rv.Interface().([]interface{}); ok {
+			return arr, false, nil
+		}
+
+		arr := make([]interface{}, rv.Len())
+		for i := range arr {
+			arr[i] = rv.Index(i).Interface()
+		}
+		return arr, false, nil
+
+	case reflect.Struct:
+		m, err := structToMap(rv)
+		return m, false, err
+
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return nil, false, &ErrUnsupportedType{Type: rv.Type()}
+
+	default:
+		return rv.Interface(), true, nil
+	}
+}
+
+// isLeafType reports whether rv should be treated as an opaque scalar rather than expanded, even
+// though its Kind() might otherwise be walked (e.g. time.Time is a struct).
+func isLeafType(rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return true
+	}
+	if rv.Type() == timeType {
+		return true
+	}
+	if rv.Type().Implements(marshalerType) {
+		return true
+	}
+	return false
+}
+
+// structToMap converts a struct to a map[string]interface{} the way json.Marshal followed by
+// json.Unmarshal into map[string]interface{} would: exported fields only, renamed/omitted per
+// their `json` tag, with anonymous struct fields promoted into the parent map.
+func structToMap(rv reflect.Value) (map[string]interface{}, error) {
+	out := make(map[string]interface{})
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, omitempty, skip := jsonTag(field)
+		if skip {
+			continue
+		}
+
+		fv := rv.Field(i)
+
+		if field.Anonymous {
+			ev := fv
+			for ev.Kind() == reflect.Ptr {
+				if ev.IsNil() {
+					ev = reflect.Value{}
+					break
+				}
+				ev = ev.Elem()
+			}
+
+			if ev.IsValid() && ev.Kind() == reflect.Struct && !isLeafType(ev) {
+				embedded, err := structToMap(ev)
+				if err != nil {
+					return nil, err
+				}
+				for k, v := range embedded {
+					out[k] = v
+				}
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if omitempty && isEmptyValue(fv) {
+			continue
+		}
+
+		out[name] = fv.Interface()
+	}
+
+	return out, nil
+}
+
+// jsonTag parses field's `json` struct tag the way encoding/json does.
+func jsonTag(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field.Name, false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+
+	return name, omitempty, false
+}
+
+// isEmptyValue mirrors encoding/json's definition of "empty" for the omitempty tag option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type contact struct {
+	address
+	Name    string            `json:"name"`
+	Tags    []string          `json:"tags"`
+	Created time.Time         `json:"created"`
+	Meta    map[string]string `json:"meta,omitempty"`
+	secret  string
+	Skipped string `json:"-"`
+}
+
+func TestFlattenValueStruct(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := contact{
+		address: address{City: "Springfield"},
+		Name:    "Homer",
+		Tags:    []string{"a", "b"},
+		Created: created,
+		secret:  "nope",
+		Skipped: "nope",
+	}
+
+	got, err := FlattenValue(c, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten struct: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"city":    "Springfield",
+		"name":    "Homer",
+		"tags":    []interface{}{"a", "b"},
+		"created": created,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestFlattenValuePointerAndGenericMap(t *testing.T) {
+	m := map[interface{}]interface{}{
+		"a": map[interface{}]interface{}{"b": "c"},
+	}
+
+	got, err := FlattenValue(&m, "", DotStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"a.b": "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestFlattenValueUnsupportedType(t *testing.T) {
+	type hasChan struct {
+		C chan int
+	}
+
+	_, err := FlattenValue(hasChan{C: make(chan int)}, "", DotStyle)
+	if _, ok := err.(*ErrUnsupportedType); !ok {
+		t.Errorf("expected *ErrUnsupportedType, got: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package flatten
+
+import "strings"
+
+// KeyEncoder controls how compound keys are rendered and parsed back into path segments. The
+// built-in DotStyle, SlashStyle, UnderscoreStyle and RailsStyle all escape any occurrence of
+// their own separator (or, for RailsStyle, of a bracket) within a raw segment, so Unflatten can
+// losslessly recover the original segments even when a map key itself contains the separator.
+type KeyEncoder interface {
+	// Encode joins prefix and sub into a compound key. top is true for the first segment under a
+	// Flatten call's prefix, which is rendered without a leading separator.
+	Encode(prefix, sub string, top bool) string
+
+	// Decode splits a compound key produced by Encode back into its original, unescaped path
+	// segments.
+	Decode(key string) []string
+}
+
+// SeparatorStyle is the presentation style of keys. It is an alias for KeyEncoder, kept so code
+// written against the original (DotStyle, SlashStyle, RailsStyle) API keeps compiling unchanged.
+type SeparatorStyle = KeyEncoder
+
+// Separate nested key components with dots, e.g. "a.b.1.c.d"
+var DotStyle KeyEncoder = separatorEncoder{sep: "."}
+
+// Separate nested key components with slashes, e.g. "a/b/1/c/d"
+var SlashStyle KeyEncoder = separatorEncoder{sep: "/"}
+
+// Separate nested key components with underscores, e.g. "a_b_1_c_d"
+var UnderscoreStyle KeyEncoder = separatorEncoder{sep: "_"}
+
+// Separate ala Rails, e.g. "a[b][c][1][d]"
+var RailsStyle KeyEncoder = railsEncoder{}
+
+// CustomStyle builds a KeyEncoder that joins segments with an arbitrary separator string, the
+// same way DotStyle, SlashStyle and UnderscoreStyle do.
+func CustomStyle(sep string) KeyEncoder {
+	return separatorEncoder{sep: sep}
+}
+
+// separatorEncoder is a KeyEncoder that joins segments with a fixed separator string, backslash-
+// escaping any occurrence of that separator (or of a backslash) within a raw segment.
+type separatorEncoder struct {
+	sep string
+}
+
+func (e separatorEncoder) Encode(prefix, sub string, top bool) string {
+	sub = escapeSeparator(sub, e.sep)
+	if top {
+		return prefix + sub
+	}
+	return prefix + e.sep + sub
+}
+
+func (e separatorEncoder) Decode(key string) []string {
+	return splitEscaped(key, e.sep)
+}
+
+// escapeSeparator backslash-escapes any backslash in s, then any occurrence of sep.
+func escapeSeparator(s, sep string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	if sep != "" {
+		s = strings.ReplaceAll(s, sep, `\`+sep)
+	}
+	return s
+}
+
+// splitEscaped splits key on sep, treating a backslash as an escape for whatever rune follows it.
+func splitEscaped(key, sep string) []string {
+	runes := []rune(key)
+	sepRunes := []rune(sep)
+
+	var segs []string
+	var cur strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			cur.WriteRune(runes[i+1])
+			i++
+			continue
+		}
+		if len(sepRunes) > 0 && runesMatchAt(runes, i, sepRunes) {
+			segs = append(segs, cur.String())
+			cur.Reset()
+			i += len(sepRunes) - 1
+			continue
+		}
+		cur.WriteRune(runes[i])
+	}
+	segs = append(segs, cur.String())
+
+	return segs
+}
+
+func runesMatchAt(runes []rune, i int, sub []rune) bool {
+	if i+len(sub) > len(runes) {
+		return false
+	}
+	for j, r := range sub {
+		if runes[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// railsEncoder implements the Rails bracket grammar, e.g. "a[b][c][1][d]", escaping a literal
+// backslash, '[' or ']' within a raw segment.
+type railsEncoder struct{}
+
+func (railsEncoder) Encode(prefix, sub string, top bool) string {
+	var b strings.Builder
+	for _, r := range sub {
+		if r == '\\' || r == '[' || r == ']' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	sub = b.String()
+
+	if top {
+		return prefix + sub
+	}
+	return prefix + "[" + sub + "]"
+}
+
+func (railsEncoder) Decode(key string) []string {
+	runes := []rune(key)
+	n := len(runes)
+	i := 0
+
+	readSegment := func(stop rune) string {
+		var b strings.Builder
+		for i < n {
+			r := runes[i]
+			if r == '\\' && i+1 < n {
+				b.WriteRune(runes[i+1])
+				i += 2
+				continue
+			}
+			if r == stop {
+				break
+			}
+			b.WriteRune(r)
+			i++
+		}
+		return b.String()
+	}
+
+	segs := []string{readSegment('[')}
+	for i < n && runes[i] == '[' {
+		i++ // consume '['
+		segs = append(segs, readSegment(']'))
+		if i < n && runes[i] == ']' {
+			i++
+		}
+	}
+
+	return segs
+}
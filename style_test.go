@@ -0,0 +1,83 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyEscapingRoundTrip(t *testing.T) {
+	nested := map[string]interface{}{
+		"a.b": map[string]interface{}{"c": 1.0},
+	}
+
+	styles := []SeparatorStyle{DotStyle, SlashStyle, UnderscoreStyle, RailsStyle, CustomStyle("|")}
+
+	for _, style := range styles {
+		flat, err := Flatten(nested, "", style)
+		if err != nil {
+			t.Fatalf("failed to flatten: %v", err)
+		}
+
+		got, err := Unflatten(flat, style)
+		if err != nil {
+			t.Fatalf("failed to unflatten: %v", err)
+		}
+
+		if !reflect.DeepEqual(got, nested) {
+			t.Errorf("round-trip mismatch, got: %v want: %v", got, nested)
+		}
+	}
+}
+
+func TestUnderscoreStyle(t *testing.T) {
+	nested := map[string]interface{}{
+		"a": map[string]interface{}{"b": []interface{}{"x", map[string]interface{}{"c": "y"}}},
+	}
+
+	got, err := Flatten(nested, "", UnderscoreStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"a_b_0":   "x",
+		"a_b_1_c": "y",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestCustomStyle(t *testing.T) {
+	nested := map[string]interface{}{"a": map[string]interface{}{"b": "c"}}
+
+	got, err := Flatten(nested, "", CustomStyle("::"))
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	want := map[string]interface{}{"a::b": "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mismatch, got: %v want: %v", got, want)
+	}
+}
+
+func TestRailsStyleEscapesBrackets(t *testing.T) {
+	nested := map[string]interface{}{
+		"wei[rd]": map[string]interface{}{"c": 1.0},
+	}
+
+	flat, err := Flatten(nested, "", RailsStyle)
+	if err != nil {
+		t.Fatalf("failed to flatten: %v", err)
+	}
+
+	got, err := Unflatten(flat, RailsStyle)
+	if err != nil {
+		t.Fatalf("failed to unflatten: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, nested) {
+		t.Errorf("round-trip mismatch, got: %v want: %v", got, nested)
+	}
+}
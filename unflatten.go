@@ -0,0 +1,138 @@
+package flatten
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ErrIllegalKey is returned by Unflatten when a flat key is used both as a leaf
+// value and as the parent of other keys, e.g. {"a": 1, "a.b": 2}.
+type ErrIllegalKey struct {
+	Key string
+}
+
+func (e *ErrIllegalKey) Error() string {
+	return fmt.Sprintf("flatten: illegal key %q: used as both a leaf and a parent node", e.Key)
+}
+
+// Unflatten reverses Flatten: it takes a flat map whose keys are compound names like
+// "a.b.1.c" (or the slash/Rails equivalents) and reconstructs the original nested
+// map, materializing a []interface{} wherever every sibling segment at a level
+// is a non-negative integer written without leading zeros.  It returns an *ErrIllegalKey if the flat map
+// uses the same prefix as both a leaf and a node.
+func Unflatten(flat map[string]interface{}, style SeparatorStyle) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := make(map[string]interface{})
+	for _, k := range keys {
+		segs := style.Decode(k)
+		if err := assignPath(root, k, segs, flat[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	for k, v := range root {
+		root[k] = arrayify(v)
+	}
+
+	return root, nil
+}
+
+// UnflattenString reverses FlattenString: it parses a flat JSON object and
+// reconstructs the nested JSON it came from.
+func UnflattenString(flatstr string, style SeparatorStyle) (string, error) {
+	var flat map[string]interface{}
+	err := json.Unmarshal([]byte(flatstr), &flat)
+	if err != nil {
+		return "", err
+	}
+
+	nested, err := Unflatten(flat, style)
+	if err != nil {
+		return "", err
+	}
+
+	nestedb, err := json.Marshal(&nested)
+	if err != nil {
+		return "", err
+	}
+
+	return string(nestedb), nil
+}
+
+// assignPath walks (creating as needed) the map nodes named by segs and sets the
+// leaf to value, returning an *ErrIllegalKey (reporting the original, still-encoded key) on a
+// leaf/node conflict.
+func assignPath(root map[string]interface{}, key string, segs []string, value interface{}) error {
+	node := root
+
+	for i, seg := range segs {
+		if i == len(segs)-1 {
+			if existing, ok := node[seg]; ok {
+				if _, isMap := existing.(map[string]interface{}); isMap {
+					return &ErrIllegalKey{Key: key}
+				}
+			}
+			node[seg] = value
+			return nil
+		}
+
+		next, ok := node[seg]
+		if !ok {
+			child := make(map[string]interface{})
+			node[seg] = child
+			node = child
+			continue
+		}
+
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return &ErrIllegalKey{Key: key}
+		}
+		node = child
+	}
+
+	return nil
+}
+
+// arrayify recursively turns any map[string]interface{} whose keys are all
+// non-negative integers (with no leading zeros) into a []interface{}, sized to
+// max(index)+1 with nil filling any gaps.
+func arrayify(v interface{}) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	for k, sub := range m {
+		m[k] = arrayify(sub)
+	}
+
+	maxIdx := -1
+	for k := range m {
+		idx, err := strconv.Atoi(k)
+		if err != nil || idx < 0 || strconv.Itoa(idx) != k {
+			return m
+		}
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+	}
+
+	if maxIdx < 0 {
+		return m
+	}
+
+	arr := make([]interface{}, maxIdx+1)
+	for k, sub := range m {
+		idx, _ := strconv.Atoi(k)
+		arr[idx] = sub
+	}
+	return arr
+}
@@ -0,0 +1,143 @@
+package flatten
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUnflatten(t *testing.T) {
+	cases := []struct {
+		flat  map[string]interface{}
+		want  string
+		style SeparatorStyle
+	}{
+		{
+			map[string]interface{}{
+				"foo.jim":      "bean",
+				"fee":          "bar",
+				"n1.alist.0":   "a",
+				"n1.alist.1":   "b",
+				"n1.alist.2":   "c",
+				"n1.alist.3.d": "other",
+				"n1.alist.3.e": "another",
+			},
+			`{
+				"foo": { "jim": "bean" },
+				"fee": "bar",
+				"n1": {
+					"alist": ["a", "b", "c", { "d": "other", "e": "another" }]
+				}
+			}`,
+			DotStyle,
+		},
+		{
+			map[string]interface{}{
+				"foo[jim]":        "bean",
+				"fee":             "bar",
+				"n1[alist][0]":    "a",
+				"n1[alist][1][d]": "other",
+			},
+			`{
+				"foo": { "jim": "bean" },
+				"fee": "bar",
+				"n1": { "alist": ["a", { "d": "other" }] }
+			}`,
+			RailsStyle,
+		},
+	}
+
+	for i, test := range cases {
+		got, err := Unflatten(test.flat, test.style)
+		if err != nil {
+			t.Errorf("%d: failed to unflatten: %v", i+1, err)
+			continue
+		}
+
+		var want map[string]interface{}
+		if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+			t.Fatalf("%d: failed to unmarshal want: %v", i+1, err)
+		}
+
+		gotb, _ := json.Marshal(got)
+		var gotNorm map[string]interface{}
+		json.Unmarshal(gotb, &gotNorm)
+
+		if !reflect.DeepEqual(gotNorm, want) {
+			t.Errorf("%d: mismatch, got: %v want: %v", i+1, gotNorm, want)
+		}
+	}
+}
+
+func TestUnflattenIllegalKey(t *testing.T) {
+	flat := map[string]interface{}{
+		"a":   1,
+		"a.b": 2,
+	}
+
+	_, err := Unflatten(flat, DotStyle)
+	if _, ok := err.(*ErrIllegalKey); !ok {
+		t.Errorf("expected *ErrIllegalKey, got: %v", err)
+	}
+}
+
+func TestUnflattenIllegalKeyReportsOriginalStyle(t *testing.T) {
+	flat := map[string]interface{}{
+		"a":   1,
+		"a/b": 2,
+	}
+
+	_, err := Unflatten(flat, SlashStyle)
+	illegal, ok := err.(*ErrIllegalKey)
+	if !ok {
+		t.Fatalf("expected *ErrIllegalKey, got: %v", err)
+	}
+	if illegal.Key != "a/b" {
+		t.Errorf("expected illegal key %q, got: %q", "a/b", illegal.Key)
+	}
+}
+
+// TestFlattenUnflattenRoundTrip checks that Flatten(Unflatten(x)) == x for the
+// JSON documents used in TestFlatten and TestFlattenString.
+func TestFlattenUnflattenRoundTrip(t *testing.T) {
+	docs := []string{
+		`{
+			"foo": { "jim":"bean" },
+			"fee": "bar",
+			"n1": {
+				"alist": ["a", "b", "c", { "d": "other", "e": "another" }]
+			},
+			"number": 1.4567,
+			"bool": true
+		}`,
+		`{ "a": { "b": { "c": { "d": "e" } } }, "number": 1.4567, "bool": true }`,
+	}
+
+	for i, doc := range docs {
+		for _, style := range []SeparatorStyle{DotStyle, SlashStyle, RailsStyle} {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(doc), &m); err != nil {
+				t.Fatalf("%d: failed to unmarshal test: %v", i+1, err)
+			}
+
+			flat, err := Flatten(m, "", style)
+			if err != nil {
+				t.Fatalf("%d: failed to flatten: %v", i+1, err)
+			}
+
+			nested, err := Unflatten(flat, style)
+			if err != nil {
+				t.Fatalf("%d: failed to unflatten: %v", i+1, err)
+			}
+
+			roundTripped, err := Flatten(nested, "", style)
+			if err != nil {
+				t.Fatalf("%d: failed to re-flatten: %v", i+1, err)
+			}
+
+			if !reflect.DeepEqual(flat, roundTripped) {
+				t.Errorf("%d/%v: round-trip mismatch, got: %v want: %v", i+1, style, roundTripped, flat)
+			}
+		}
+	}
+}